@@ -5,33 +5,134 @@ import (
 	"io"
 	"net"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/adrianosela/deaconn/deadline"
 )
 
-const readBufferSize = 5242880 // 5 MB
+const defaultReadBufferSize = 5242880 // 5 MB
 
 type txResult struct {
 	n   int
 	err error
 }
 
+type writeRequest struct {
+	data   []byte
+	result chan txResult
+}
+
+// Interrupter aborts a Write that is blocked on the underlying
+// connection once its deadline expires, for connections that do not
+// support SetWriteDeadline themselves. It is not called when a Read
+// deadline expires: Read returns the timeout and the background
+// reader's pending inner.Read is left running.
+// The default Interrupter closes the whole connection; supply a
+// gentler one via WithInterrupter if that is too coarse, e.g. because
+// it would also tear down state shared with other in-flight calls.
+type Interrupter func(inner net.Conn) error
+
+func defaultInterrupter(inner net.Conn) error {
+	return inner.Close()
+}
+
+// CloseReader is implemented by connections that support closing
+// only their read side, such as *net.TCPConn. A *conn delegates
+// CloseRead to inner when inner implements this interface.
+type CloseReader interface {
+	CloseRead() error
+}
+
+// CloseWriter is implemented by connections that support closing
+// only their write side, such as *net.TCPConn. A *conn delegates
+// CloseWrite to inner when inner implements this interface.
+type CloseWriter interface {
+	CloseWrite() error
+}
+
+// Option configures a conn constructed by WithDeadlines.
+type Option func(*conn)
+
+// WithInterrupter overrides how an in-flight Write is aborted when
+// its deadline expires on a connection that has no native write
+// deadline support of its own.
+func WithInterrupter(i Interrupter) Option {
+	return func(c *conn) { c.interrupt = i }
+}
+
+// WithReadBufferSize overrides the size of the scratch buffers used
+// to read from inner. It has no effect if WithBufferPool is also
+// given, since the pool then owns buffer sizing.
+func WithReadBufferSize(n int) Option {
+	return func(c *conn) { c.readBufferSize = n }
+}
+
+// WithBufferPool overrides the *sync.Pool that scratch read buffers
+// are drawn from. By default each conn uses a private pool of
+// readBufferSize-sized buffers; passing a shared pool lets many conns
+// amortize allocations across each other.
+func WithBufferPool(p *sync.Pool) Option {
+	return func(c *conn) { c.bufferPool = p }
+}
+
+// WithMaxPendingBytes caps how many bytes read from inner may sit
+// unread (buffered in rxDataPending) before the reader loop blocks
+// and stops reading further, providing backpressure against a slow
+// consumer. The default, 0, means unbounded.
+func WithMaxPendingBytes(n int) Option {
+	return func(c *conn) { c.maxPendingBytes = n }
+}
+
 type conn struct {
 	inner net.Conn
 
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 
-	rxData        chan []byte
-	rxDataPending []byte
+	interrupt Interrupter
+
+	// readDelegated and writeDelegated are true when inner has its
+	// own working SetReadDeadline/SetWriteDeadline, in which case
+	// Read/Write pass straight through to inner instead of going
+	// through the goroutines and channels below.
+	readDelegated  bool
+	writeDelegated bool
+
+	readBufferSize int
+	bufferPool     *sync.Pool
+
+	rxData           chan []byte
+	rxDataPending    []byte
+	rxDataPendingBuf []byte // full pool buffer backing rxDataPending
+
+	// rxClosed and txClosed back CloseRead/CloseWrite when inner does
+	// not implement CloseReader/CloseWriter itself. They are distinct
+	// from ctx, which tears down the whole connection.
+	rxClosed     chan struct{}
+	rxClosedOnce sync.Once
+	txClosed     chan struct{}
+	txClosedOnce sync.Once
+
+	maxPendingBytes int
+	pendingMu       sync.Mutex
+	pendingCond     *sync.Cond
+	pendingBytes    int
+
+	writeReqs chan writeRequest
 
 	rxDeadline deadline.Deadline
 	txDeadline deadline.Deadline
 }
 
-// WithDeadlines adds support for deadlines to a given net.Conn.
-func WithDeadlines(inner net.Conn) net.Conn {
+// WithDeadlines adds support for deadlines to a given net.Conn. If
+// inner already has working SetReadDeadline/SetWriteDeadline support,
+// those calls are delegated to directly and Read/Write pass through
+// with no extra goroutines; otherwise deadlines are enforced in
+// software: a Read whose deadline expires returns a timeout error,
+// and a blocked Write is aborted via an Interrupter once its deadline
+// expires (see WithInterrupter).
+func WithDeadlines(inner net.Conn, opts ...Option) net.Conn {
 	ctx, ctxCancel := context.WithCancel(context.Background())
 
 	c := &conn{
@@ -40,42 +141,162 @@ func WithDeadlines(inner net.Conn) net.Conn {
 		ctx:       ctx,
 		ctxCancel: ctxCancel,
 
+		interrupt: defaultInterrupter,
+
+		readDelegated:  inner.SetReadDeadline(time.Time{}) == nil,
+		writeDelegated: inner.SetWriteDeadline(time.Time{}) == nil,
+
+		readBufferSize: defaultReadBufferSize,
+
 		rxData:        make(chan []byte),
 		rxDataPending: []byte{},
 
+		rxClosed: make(chan struct{}),
+		txClosed: make(chan struct{}),
+
+		writeReqs: make(chan writeRequest),
+
 		rxDeadline: deadline.New(),
 		txDeadline: deadline.New(),
 	}
+	c.pendingCond = sync.NewCond(&c.pendingMu)
 
-	go c.continouslyReadIntoBuffer()
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.bufferPool == nil {
+		bufSize := c.readBufferSize
+		c.bufferPool = &sync.Pool{
+			New: func() interface{} { return make([]byte, bufSize) },
+		}
+	}
+
+	if !c.readDelegated {
+		go c.continouslyReadIntoBuffer()
+	}
+	if !c.writeDelegated {
+		go c.continouslyWriteFromRequests()
+	}
 
 	return c
 }
 
+// waitForPendingBudget blocks the reader loop while MaxPendingBytes
+// is set and exceeded, applying backpressure against a slow consumer
+// instead of letting rxDataPending grow unboundedly. It returns false
+// if the connection, or just its read side, was closed while waiting.
+func (c *conn) waitForPendingBudget() bool {
+	if c.maxPendingBytes <= 0 {
+		return true
+	}
+
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	for c.pendingBytes >= c.maxPendingBytes {
+		select {
+		case <-c.ctx.Done():
+			return false
+		case <-c.rxClosed:
+			return false
+		default:
+			c.pendingCond.Wait()
+		}
+	}
+	return true
+}
+
+func (c *conn) addPendingBytes(n int) {
+	if c.maxPendingBytes <= 0 {
+		return
+	}
+	c.pendingMu.Lock()
+	c.pendingBytes += n
+	c.pendingMu.Unlock()
+}
+
+func (c *conn) releasePendingBytes(n int) {
+	if c.maxPendingBytes <= 0 {
+		return
+	}
+	c.pendingMu.Lock()
+	c.pendingBytes -= n
+	c.pendingCond.Broadcast()
+	c.pendingMu.Unlock()
+}
+
+// returnBufferToPool returns a buffer obtained from c.bufferPool back
+// to it, restoring its full capacity first.
+func (c *conn) returnBufferToPool(buf []byte) {
+	c.bufferPool.Put(buf[:cap(buf)])
+}
+
 func (c *conn) continouslyReadIntoBuffer() {
 	defer close(c.rxData)
-	defer c.Close()
-
-	buf := make([]byte, readBufferSize)
 
 	for {
+		if !c.waitForPendingBudget() {
+			return
+		}
+
 		select {
 		case <-c.ctx.Done():
 			return
+		case <-c.rxClosed:
+			return
 		default:
+			buf := c.bufferPool.Get().([]byte)
+
 			n, err := c.inner.Read(buf)
 			if n > 0 {
-				copied := make([]byte, n)
-				copy(copied, buf[:n])
-				c.rxData <- copied[:n]
+				data := buf[:n]
+				c.addPendingBytes(n)
+
+				select {
+				case c.rxData <- data:
+				case <-c.ctx.Done():
+					c.releasePendingBytes(n)
+					return
+				case <-c.rxClosed:
+					c.releasePendingBytes(n)
+					return
+				}
+			} else {
+				c.returnBufferToPool(buf)
 			}
 			if err != nil {
+				// A real read error (including EOF) means the whole
+				// connection is no longer usable, not just this
+				// half, so tear it all down. This must not happen on
+				// the ctx.Done()/rxClosed paths above, since those
+				// can mean only the read side was closed and Write
+				// needs to keep working.
+				c.Close()
 				return
 			}
 		}
 	}
 }
 
+// continouslyWriteFromRequests is the single long-lived writer for
+// connections whose inner conn has no native deadline support. It
+// replaces spawning a goroutine per Write. A write that is abandoned
+// because its deadline expired is not stopped here; Write aborts it
+// by calling the Interrupter on inner, so that its bytes do not land
+// on the wire after the caller was already told the write failed.
+func (c *conn) continouslyWriteFromRequests() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case req := <-c.writeReqs:
+			n, err := c.inner.Write(req.data)
+			req.result <- txResult{n, err}
+		}
+	}
+}
+
 // Read reads data from the connection.
 // Read can be made to time out and return an error after a fixed
 // time limit; see SetDeadline and SetReadDeadline.
@@ -84,10 +305,23 @@ func (c *conn) Read(b []byte) (int, error) {
 		return 0, nil
 	}
 
+	select {
+	case <-c.rxClosed:
+		return 0, io.EOF
+	default:
+	}
+
+	if c.readDelegated {
+		return c.inner.Read(b)
+	}
+
 	select {
 	// connection closed
 	case <-c.ctx.Done():
 		return 0, io.EOF
+	// read side closed via CloseRead
+	case <-c.rxClosed:
+		return 0, io.EOF
 	// deadline exceeded
 	case <-c.rxDeadline.Done():
 		return 0, os.ErrDeadlineExceeded
@@ -96,6 +330,11 @@ func (c *conn) Read(b []byte) (int, error) {
 		if len(c.rxDataPending) > 0 {
 			n := copy(b, c.rxDataPending)
 			c.rxDataPending = c.rxDataPending[n:] // adjust pending data
+			c.releasePendingBytes(n)
+			if len(c.rxDataPending) == 0 {
+				c.returnBufferToPool(c.rxDataPendingBuf)
+				c.rxDataPendingBuf = nil
+			}
 			return n, nil
 		}
 	}
@@ -104,6 +343,9 @@ func (c *conn) Read(b []byte) (int, error) {
 	// connection closed
 	case <-c.ctx.Done():
 		return 0, io.EOF
+	// read side closed via CloseRead
+	case <-c.rxClosed:
+		return 0, io.EOF
 	// deadline exceeded
 	case <-c.rxDeadline.Done():
 		return 0, os.ErrDeadlineExceeded
@@ -114,11 +356,16 @@ func (c *conn) Read(b []byte) (int, error) {
 		}
 
 		n := copy(b, data)
+		c.releasePendingBytes(n)
 		if n < len(data) {
-			// if not all data fits in the given buffer,
-			// we append the bytes to the pending data
-			// buffer to be read on the next Read() call.
-			c.rxDataPending = append(c.rxDataPending, data[n:]...)
+			// ownership of the pool buffer backing data is handed
+			// to rxDataPending; it is returned to the pool once
+			// fully drained by a subsequent Read call, instead of
+			// copying its remainder into a separately grown slice.
+			c.rxDataPending = data[n:]
+			c.rxDataPendingBuf = data
+		} else {
+			c.returnBufferToPool(data)
 		}
 		return n, nil
 	}
@@ -132,36 +379,86 @@ func (c *conn) Write(b []byte) (n int, err error) {
 		return 0, nil
 	}
 
+	select {
+	case <-c.txClosed:
+		return 0, net.ErrClosed
+	default:
+	}
+
+	if c.writeDelegated {
+		return c.inner.Write(b)
+	}
+
 	copied := make([]byte, len(b))
 	copy(copied, b)
 
-	txResultChan := make(chan txResult)
-	go func() {
-		defer close(txResultChan)
+	result := make(chan txResult, 1)
 
-		n, err := c.inner.Write(copied)
-		txResultChan <- txResult{int(n), err}
-	}()
+	select {
+	case <-c.ctx.Done():
+		return 0, io.EOF
+	case c.writeReqs <- writeRequest{data: copied, result: result}:
+	}
 
 	select {
 	// connection closed
 	case <-c.ctx.Done():
 		return 0, io.EOF
-	// deadline exceeded
+	// deadline exceeded: the write above is still running in the
+	// background, so interrupt it instead of letting its bytes land
+	// on the wire after we have already reported failure.
 	case <-c.txDeadline.Done():
+		c.interrupt(c.inner)
 		return 0, os.ErrDeadlineExceeded
 	// write completed
-	case result := <-txResultChan:
+	case result := <-result:
 		return result.n, result.err
 	}
 }
 
 // Close closes the connection.
 func (c *conn) Close() error {
-	defer c.ctxCancel()
+	defer func() {
+		c.ctxCancel()
+		c.pendingMu.Lock()
+		c.pendingCond.Broadcast()
+		c.pendingMu.Unlock()
+	}()
 	return c.inner.Close()
 }
 
+// CloseRead shuts down the read side of the connection, causing
+// future Read calls to return io.EOF while leaving Write unaffected.
+// If inner implements CloseReader, CloseRead delegates to it;
+// otherwise the internal reader is stopped the next time it is
+// unblocked (by incoming data, EOF, or a subsequent full Close), since
+// there is no portable way to interrupt its blocked inner.Read call
+// without closing inner outright.
+func (c *conn) CloseRead() error {
+	if cr, ok := c.inner.(CloseReader); ok {
+		return cr.CloseRead()
+	}
+	c.rxClosedOnce.Do(func() {
+		close(c.rxClosed)
+		c.pendingMu.Lock()
+		c.pendingCond.Broadcast()
+		c.pendingMu.Unlock()
+	})
+	return nil
+}
+
+// CloseWrite shuts down the write side of the connection, causing
+// future Write calls to fail with net.ErrClosed while leaving the
+// reader loop alive to drain the peer. If inner implements
+// CloseWriter, CloseWrite delegates to it.
+func (c *conn) CloseWrite() error {
+	if cw, ok := c.inner.(CloseWriter); ok {
+		return cw.CloseWrite()
+	}
+	c.txClosedOnce.Do(func() { close(c.txClosed) })
+	return nil
+}
+
 // LocalAddr returns the local network address, if known.
 func (c *conn) LocalAddr() net.Addr {
 	return c.inner.LocalAddr()
@@ -204,6 +501,10 @@ func (c *conn) SetDeadline(t time.Time) error {
 // and any currently-blocked Read call.
 // A zero value for t means Read will not time out.
 func (c *conn) SetReadDeadline(t time.Time) error {
+	if c.readDelegated {
+		return c.inner.SetReadDeadline(t)
+	}
+
 	select {
 	case <-c.ctx.Done():
 		return net.ErrClosed
@@ -219,6 +520,10 @@ func (c *conn) SetReadDeadline(t time.Time) error {
 // some of the data was successfully written.
 // A zero value for t means Write will not time out.
 func (c *conn) SetWriteDeadline(t time.Time) error {
+	if c.writeDelegated {
+		return c.inner.SetWriteDeadline(t)
+	}
+
 	select {
 	case <-c.ctx.Done():
 		return net.ErrClosed
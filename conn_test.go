@@ -0,0 +1,151 @@
+package deaconn
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adrianosela/deaconn/inproc"
+)
+
+// newInprocPair returns a connected pair of in-process conns whose
+// SetReadDeadline/SetWriteDeadline always fail, so wrapping either end
+// with WithDeadlines exercises the software-enforced deadline path
+// (continouslyReadIntoBuffer, the buffer pool, waitForPendingBudget,
+// continouslyWriteFromRequests) instead of delegating straight through
+// to an inner conn that already supports deadlines, like net.Pipe does.
+func newInprocPair(t testing.TB) (a, b net.Conn) {
+	t.Helper()
+
+	l, dial := inproc.Listener("test")
+	t.Cleanup(func() { l.Close() })
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+
+	client, err := dial(context.Background(), "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	select {
+	case server := <-accepted:
+		return client, server
+	case <-time.After(time.Second):
+		t.Fatal("Accept never received the dialed connection")
+		return nil, nil
+	}
+}
+
+func TestCloseReadUnblocksPendingBudgetWait(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+
+	wrapped := WithDeadlines(client, WithMaxPendingBytes(1))
+	defer wrapped.Close()
+
+	c := wrapped.(*conn)
+
+	// saturate the pending budget so waitForPendingBudget blocks.
+	c.addPendingBytes(10)
+
+	parked := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		close(parked)
+		c.waitForPendingBudget()
+		close(stopped)
+	}()
+
+	<-parked
+	// give the goroutine a moment to actually reach cond.Wait().
+	time.Sleep(20 * time.Millisecond)
+
+	if err := c.CloseRead(); err != nil {
+		t.Fatalf("CloseRead: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("waitForPendingBudget did not unblock after CloseRead")
+	}
+}
+
+func TestCloseReadCausesReadEOF(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+
+	wrapped := WithDeadlines(client)
+	defer wrapped.Close()
+
+	c := wrapped.(*conn)
+	if err := c.CloseRead(); err != nil {
+		t.Fatalf("CloseRead: %v", err)
+	}
+
+	if _, err := wrapped.Read(make([]byte, 16)); err != io.EOF {
+		t.Fatalf("Read after CloseRead = %v, want io.EOF", err)
+	}
+}
+
+// TestCloseReadLeavesWriteFunctional confirms CloseRead only shuts
+// down the read side: after CloseRead (while the reader goroutine is
+// still blocked in inner.Read, since the server never writes), Write
+// must keep working so the connection can still flush to the peer.
+func TestCloseReadLeavesWriteFunctional(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+
+	wrapped := WithDeadlines(client)
+	defer wrapped.Close()
+
+	if err := wrapped.(*conn).CloseRead(); err != nil {
+		t.Fatalf("CloseRead: %v", err)
+	}
+
+	received := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(server, buf); err == nil {
+			received <- buf
+		}
+	}()
+
+	if _, err := wrapped.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write after CloseRead = %v, want success", err)
+	}
+
+	select {
+	case buf := <-received:
+		if string(buf) != "hello" {
+			t.Fatalf("server read %q, want %q", buf, "hello")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("server never received the post-CloseRead write")
+	}
+}
+
+func TestCloseWriteCausesWriteErrClosed(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+
+	wrapped := WithDeadlines(client)
+	defer wrapped.Close()
+
+	c := wrapped.(*conn)
+	if err := c.CloseWrite(); err != nil {
+		t.Fatalf("CloseWrite: %v", err)
+	}
+
+	if _, err := wrapped.Write([]byte("hi")); err != net.ErrClosed {
+		t.Fatalf("Write after CloseWrite = %v, want net.ErrClosed", err)
+	}
+}
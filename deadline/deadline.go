@@ -0,0 +1,90 @@
+// Package deadline provides a primitive for composing a timer with a
+// done-channel, used to make blocking I/O calls selectable against a
+// deadline the way net.Conn implementations do internally.
+package deadline
+
+import (
+	"sync"
+	"time"
+)
+
+// Deadline represents a deadline that can be set, refreshed, or
+// cleared, and waited upon via a channel that is closed when the
+// deadline elapses. It follows the pipeDeadline pattern used by
+// net.Pipe: a mutex-guarded timer plus a cancel channel that is
+// closed on expiry and swapped for a fresh one on refresh, so Set and
+// Done are both safe to call from different goroutines, such as a
+// caller refreshing the deadline while another goroutine is blocked
+// on Done().
+type Deadline struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{}
+}
+
+// New returns a new Deadline with no expiry set.
+func New() Deadline {
+	return Deadline{cancel: make(chan struct{})}
+}
+
+// Set sets the deadline to t, replacing any previously set deadline.
+// A zero value for t clears the deadline so Done will never fire.
+func (d *Deadline) Set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cancel == nil {
+		d.cancel = make(chan struct{})
+	}
+
+	if d.timer != nil && !d.timer.Stop() {
+		// the timer already fired and is racing to close d.cancel;
+		// wait for it so we don't close an already-closed channel
+		// or swap out from under it.
+		<-d.cancel
+	}
+	d.timer = nil
+
+	closed := isClosed(d.cancel)
+
+	if t.IsZero() {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		return
+	}
+
+	if dur := time.Until(t); dur > 0 {
+		if closed {
+			d.cancel = make(chan struct{})
+		}
+		cancel := d.cancel
+		d.timer = time.AfterFunc(dur, func() { close(cancel) })
+		return
+	}
+
+	if !closed {
+		close(d.cancel)
+	}
+}
+
+// Done returns a channel that is closed when the deadline elapses.
+// The channel returned is only valid until the next call to Set.
+func (d *Deadline) Done() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cancel == nil {
+		d.cancel = make(chan struct{})
+	}
+	return d.cancel
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package deadline
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeadlineExpires(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+
+	select {
+	case <-d.Done():
+	case <-time.After(time.Second):
+		t.Fatal("deadline did not expire in time")
+	}
+}
+
+func TestDeadlineZeroClears(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(10 * time.Millisecond))
+	d.Set(time.Time{})
+
+	select {
+	case <-d.Done():
+		t.Fatal("deadline fired after being cleared")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDeadlineInThePast(t *testing.T) {
+	var d Deadline
+	d.Set(time.Now().Add(-time.Second))
+
+	select {
+	case <-d.Done():
+	default:
+		t.Fatal("deadline in the past did not fire immediately")
+	}
+}
+
+// TestDeadlineConcurrentSetAndDone exercises Set being called from one
+// goroutine while another reads Done(), the pattern conn.Read and
+// conn.SetReadDeadline use in practice. Run with -race.
+func TestDeadlineConcurrentSetAndDone(t *testing.T) {
+	var d Deadline
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			d.Set(time.Now().Add(time.Millisecond))
+			d.Set(time.Time{})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			done := d.Done()
+			select {
+			case <-done:
+			default:
+			}
+		}
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,104 @@
+// Package inproc provides an in-memory net.Listener and Dialer pair
+// backed by io.Pipe, for wiring an in-process server and client (for
+// example a gRPC server dialed via grpc.WithContextDialer) without
+// opening a real socket. The returned net.Conn does not implement
+// deadlines itself; compose it with deaconn.WithDeadlines to exercise
+// deadline behavior against a synthetic connection.
+package inproc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var errDeadlineNotSupported = errors.New("inproc: deadlines not supported, wrap with deaconn.WithDeadlines")
+
+// addr is a synthetic net.Addr for in-process connections.
+type addr struct {
+	network string
+	address string
+}
+
+// Network returns the address's network name.
+func (a addr) Network() string { return a.network }
+
+// String returns the address's string representation.
+func (a addr) String() string { return a.address }
+
+// conn is a net.Conn implementation backed by a pair of io.Pipe
+// streams, one per direction.
+type conn struct {
+	pr *io.PipeReader
+	pw *io.PipeWriter
+
+	local  net.Addr
+	remote net.Addr
+
+	closeOnce sync.Once
+}
+
+// newConnPair returns two conns wired to each other: anything written
+// to one is readable from the other, and vice versa.
+func newConnPair(network string) (a, b *conn) {
+	clientAddr := addr{network: network, address: "inproc-client"}
+	serverAddr := addr{network: network, address: "inproc-server"}
+
+	clientToServerR, clientToServerW := io.Pipe()
+	serverToClientR, serverToClientW := io.Pipe()
+
+	client := &conn{pr: serverToClientR, pw: clientToServerW, local: clientAddr, remote: serverAddr}
+	server := &conn{pr: clientToServerR, pw: serverToClientW, local: serverAddr, remote: clientAddr}
+
+	return client, server
+}
+
+// Read reads data from the connection.
+func (c *conn) Read(b []byte) (int, error) {
+	return c.pr.Read(b)
+}
+
+// Write writes data to the connection.
+func (c *conn) Write(b []byte) (int, error) {
+	return c.pw.Write(b)
+}
+
+// Close closes the connection. Any pending Read or Write on this
+// conn, or on its peer, is unblocked and returns io.ErrClosedPipe.
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.pr.CloseWithError(io.ErrClosedPipe)
+		c.pw.CloseWithError(io.ErrClosedPipe)
+	})
+	return nil
+}
+
+// LocalAddr returns the local network address, if known.
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the remote network address, if known.
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline is not supported by conn; compose it with
+// deaconn.WithDeadlines to add deadline support.
+func (c *conn) SetDeadline(t time.Time) error {
+	return errDeadlineNotSupported
+}
+
+// SetReadDeadline is not supported by conn; compose it with
+// deaconn.WithDeadlines to add deadline support.
+func (c *conn) SetReadDeadline(t time.Time) error {
+	return errDeadlineNotSupported
+}
+
+// SetWriteDeadline is not supported by conn; compose it with
+// deaconn.WithDeadlines to add deadline support.
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	return errDeadlineNotSupported
+}
@@ -0,0 +1,71 @@
+package inproc
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// Dialer dials an in-process Listener. It matches the signature
+// expected by grpc.WithContextDialer.
+type Dialer func(ctx context.Context, address string) (net.Conn, error)
+
+// listener is a net.Listener implementation whose Accept is paired
+// with a Dialer returned alongside it, rather than with a real socket.
+type listener struct {
+	network string
+
+	conns chan net.Conn
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Listener returns an in-process net.Listener and a Dialer paired
+// with it. Accept blocks until the Dialer is called; Close unblocks
+// any pending Accept or Dial with net.ErrClosed. network is only used
+// to populate the Network() of addresses handed out by this pair.
+func Listener(network string) (net.Listener, Dialer) {
+	l := &listener{
+		network: network,
+		conns:   make(chan net.Conn),
+		closed:  make(chan struct{}),
+	}
+	return l, l.dial
+}
+
+func (l *listener) dial(ctx context.Context, _ string) (net.Conn, error) {
+	client, server := newConnPair(l.network)
+
+	select {
+	case <-l.closed:
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case l.conns <- server:
+		return client, nil
+	}
+}
+
+// Accept waits for and returns the next connection dialed against
+// this listener's Dialer.
+func (l *listener) Accept() (net.Conn, error) {
+	select {
+	case <-l.closed:
+		return nil, net.ErrClosed
+	case c := <-l.conns:
+		return c, nil
+	}
+}
+
+// Close closes the listener. Any blocked Accept or Dial is unblocked
+// and returns net.ErrClosed.
+func (l *listener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+// Addr returns the listener's synthetic network address.
+func (l *listener) Addr() net.Addr {
+	return addr{network: l.network, address: "inproc-server"}
+}
@@ -0,0 +1,197 @@
+package inproc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/adrianosela/deaconn"
+)
+
+func TestDialAndAcceptExchangeData(t *testing.T) {
+	l, dial := Listener("inproc")
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		accepted <- c
+	}()
+
+	client, err := dial(context.Background(), "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	var server net.Conn
+	select {
+	case server = <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("Accept did not return the dialed connection")
+	}
+	defer server.Close()
+
+	// io.Pipe is an unbuffered, synchronous handoff: Write blocks until
+	// a matching Read drains it, so the two sides must run concurrently.
+	go client.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(server, buf); err != nil {
+		t.Fatalf("server.Read: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Fatalf("server read %q, want %q", buf, "ping")
+	}
+
+	go server.Write([]byte("pong"))
+	if _, err := io.ReadFull(client, buf); err != nil {
+		t.Fatalf("client.Read: %v", err)
+	}
+	if string(buf) != "pong" {
+		t.Fatalf("client read %q, want %q", buf, "pong")
+	}
+}
+
+func TestCloseUnblocksAccept(t *testing.T) {
+	l, _ := Listener("inproc")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		done <- err
+	}()
+
+	// give Accept a moment to actually block before closing.
+	time.Sleep(20 * time.Millisecond)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != net.ErrClosed {
+			t.Fatalf("Accept returned %v, want net.ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Accept did not unblock after Close")
+	}
+}
+
+func TestDialAfterCloseReturnsErrClosed(t *testing.T) {
+	l, dial := Listener("inproc")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := dial(context.Background(), ""); err != net.ErrClosed {
+		t.Fatalf("dial after Close = %v, want net.ErrClosed", err)
+	}
+}
+
+func TestDialRespectsContextCancellation(t *testing.T) {
+	l, dial := Listener("inproc")
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := dial(ctx, ""); err != ctx.Err() {
+		t.Fatalf("dial with cancelled context = %v, want %v", err, ctx.Err())
+	}
+}
+
+func TestConnCloseUnblocksPeer(t *testing.T) {
+	client, server := newConnPair("inproc")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := server.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != io.ErrClosedPipe {
+			t.Fatalf("peer Read returned %v, want io.ErrClosedPipe", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer Read did not unblock after Close")
+	}
+}
+
+// TestWithDeadlinesComposesWithDialedConn confirms an inproc conn
+// dialed through a Listener can be wrapped with deaconn.WithDeadlines
+// on both ends, and that a deadline set on the wrapped conn actually
+// fires - since conn's SetReadDeadline always fails, WithDeadlines
+// must fall back to enforcing the deadline itself rather than
+// delegating to conn.
+func TestWithDeadlinesComposesWithDialedConn(t *testing.T) {
+	l, dial := Listener("inproc")
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+
+	client, err := dial(context.Background(), "")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	var server net.Conn
+	select {
+	case server = <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("Accept did not return the dialed connection")
+	}
+	defer server.Close()
+
+	wrappedClient := deaconn.WithDeadlines(client)
+	defer wrappedClient.Close()
+	wrappedServer := deaconn.WithDeadlines(server)
+	defer wrappedServer.Close()
+
+	if err := wrappedClient.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetReadDeadline: %v", err)
+	}
+	// nothing is ever written, so this only returns once the deadline
+	// set above actually fires.
+	if _, err := wrappedClient.Read(make([]byte, 1)); !isTimeout(err) {
+		t.Fatalf("Read = %v, want a timeout error", err)
+	}
+
+	if err := wrappedClient.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatalf("SetReadDeadline (clear): %v", err)
+	}
+
+	go wrappedServer.Write([]byte("hi"))
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(wrappedClient, buf); err != nil {
+		t.Fatalf("Read after deadline expiry: %v", err)
+	}
+	if string(buf) != "hi" {
+		t.Fatalf("Read = %q, want %q", buf, "hi")
+	}
+}
+
+func isTimeout(err error) bool {
+	type timeout interface{ Timeout() bool }
+	te, ok := err.(timeout)
+	return ok && te.Timeout()
+}
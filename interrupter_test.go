@@ -0,0 +1,61 @@
+package deaconn
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestWriteDeadlineInterruptsBlockedWrite confirms that a Write
+// blocked on an unresponsive peer is aborted once its deadline
+// expires, via the default Interrupter (closing inner), instead of
+// blocking forever or letting its bytes land after the caller was
+// already told it failed.
+func TestWriteDeadlineInterruptsBlockedWrite(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+
+	wrapped := WithDeadlines(client)
+	defer wrapped.Close()
+
+	if err := wrapped.SetWriteDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetWriteDeadline: %v", err)
+	}
+
+	// server never reads, so this blocks until the deadline interrupts it.
+	if _, err := wrapped.Write(make([]byte, 16)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Write = %v, want os.ErrDeadlineExceeded", err)
+	}
+}
+
+// TestWithInterrupterIsInvokedOnWriteDeadline confirms a custom
+// Interrupter passed via WithInterrupter is used in place of the
+// default one to abort a blocked Write on deadline expiry.
+func TestWithInterrupterIsInvokedOnWriteDeadline(t *testing.T) {
+	client, server := newInprocPair(t)
+	defer server.Close()
+	defer client.Close()
+
+	invoked := make(chan struct{})
+	wrapped := WithDeadlines(client, WithInterrupter(func(inner net.Conn) error {
+		close(invoked)
+		return inner.Close()
+	}))
+	defer wrapped.Close()
+
+	if err := wrapped.SetWriteDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatalf("SetWriteDeadline: %v", err)
+	}
+
+	if _, err := wrapped.Write(make([]byte, 16)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Write = %v, want os.ErrDeadlineExceeded", err)
+	}
+
+	select {
+	case <-invoked:
+	case <-time.After(time.Second):
+		t.Fatal("custom Interrupter was never invoked")
+	}
+}
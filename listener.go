@@ -6,12 +6,15 @@ import "net"
 // deadlines support to every accepted net.Conn.
 type listener struct {
 	inner net.Listener
+	opts  []Option
 }
 
 // NewListenerWithDeadlines returns a net.Listener implementation
-// which adds deadlines support to every accepted net.Conn.
-func NewListenerWithDeadlines(inner net.Listener) net.Listener {
-	return &listener{inner: inner}
+// which adds deadlines support to every accepted net.Conn. Any opts
+// given are applied to every accepted net.Conn, the same as if they
+// had been passed to WithDeadlines directly.
+func NewListenerWithDeadlines(inner net.Listener, opts ...Option) net.Listener {
+	return &listener{inner: inner, opts: opts}
 }
 
 // Accept waits for and returns the next connection to the listener.
@@ -20,7 +23,7 @@ func (l *listener) Accept() (net.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
-	return WithDeadlines(innerConn), nil
+	return WithDeadlines(innerConn, l.opts...), nil
 }
 
 // Close closes the listener.
@@ -0,0 +1,260 @@
+package deaconn
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/adrianosela/deaconn/deadline"
+)
+
+const packetReadBufferSize = 65507 // max theoretical UDP payload size
+
+// packetRxQueueDepth bounds how many undelivered datagrams rxData may
+// hold before the reader goroutine blocks pushing a new one, applying
+// natural backpressure against a slow ReadFrom caller instead of
+// buffering an unbounded backlog of datagrams in memory.
+const packetRxQueueDepth = 64
+
+// packetDatagram is a single inbound packet, payload and source
+// address kept together so datagram boundaries are never merged.
+type packetDatagram struct {
+	payload []byte
+	addr    net.Addr
+}
+
+type packetWriteRequest struct {
+	data   []byte
+	addr   net.Addr
+	result chan txResult
+}
+
+type packetConn struct {
+	inner net.PacketConn
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	// readDelegated and writeDelegated are true when inner has its
+	// own working SetReadDeadline/SetWriteDeadline, in which case
+	// ReadFrom/WriteTo pass straight through to inner.
+	readDelegated  bool
+	writeDelegated bool
+
+	rxData chan packetDatagram
+
+	writeReqs chan packetWriteRequest
+
+	rxDeadline deadline.Deadline
+	txDeadline deadline.Deadline
+}
+
+// WithPacketDeadlines adds support for deadlines to a given net.PacketConn.
+// This is useful for UDP-style transports whose underlying deadline
+// support is unusable, such as tunneled/multiplexed UDP or userspace
+// SOCKS/Shadowsocks packet conns. If inner already has working
+// SetReadDeadline/SetWriteDeadline support, those calls are delegated
+// to directly instead.
+func WithPacketDeadlines(inner net.PacketConn) net.PacketConn {
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	pc := &packetConn{
+		inner: inner,
+
+		ctx:       ctx,
+		ctxCancel: ctxCancel,
+
+		readDelegated:  inner.SetReadDeadline(time.Time{}) == nil,
+		writeDelegated: inner.SetWriteDeadline(time.Time{}) == nil,
+
+		rxData: make(chan packetDatagram, packetRxQueueDepth),
+
+		writeReqs: make(chan packetWriteRequest),
+
+		rxDeadline: deadline.New(),
+		txDeadline: deadline.New(),
+	}
+
+	if !pc.readDelegated {
+		go pc.continouslyReadIntoBuffer()
+	}
+	if !pc.writeDelegated {
+		go pc.continouslyWriteFromRequests()
+	}
+
+	return pc
+}
+
+func (pc *packetConn) continouslyReadIntoBuffer() {
+	defer close(pc.rxData)
+	defer pc.Close()
+
+	buf := make([]byte, packetReadBufferSize)
+
+	for {
+		select {
+		case <-pc.ctx.Done():
+			return
+		default:
+			n, addr, err := pc.inner.ReadFrom(buf)
+			if n > 0 {
+				payload := make([]byte, n)
+				copy(payload, buf[:n])
+
+				select {
+				case pc.rxData <- packetDatagram{payload: payload, addr: addr}:
+				case <-pc.ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// continouslyWriteFromRequests is the single long-lived writer used
+// when inner has no native deadline support. A write whose deadline
+// expires is not stopped here; WriteTo aborts it by closing inner, so
+// that its bytes do not land on the wire after the caller has already
+// been told it failed.
+func (pc *packetConn) continouslyWriteFromRequests() {
+	for {
+		select {
+		case <-pc.ctx.Done():
+			return
+		case req := <-pc.writeReqs:
+			n, err := pc.inner.WriteTo(req.data, req.addr)
+			req.result <- txResult{n, err}
+		}
+	}
+}
+
+// ReadFrom reads a packet from the connection, copying the payload
+// into b and returning the number of bytes copied and the source
+// address. A datagram that does not fit in b is truncated, as with
+// a real net.PacketConn; datagrams are never merged or split across
+// calls.
+//
+// ReadFrom can be made to time out and return an error after a fixed
+// time limit; see SetDeadline and SetReadDeadline.
+func (pc *packetConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if pc.readDelegated {
+		return pc.inner.ReadFrom(b)
+	}
+
+	select {
+	// connection closed
+	case <-pc.ctx.Done():
+		return 0, nil, io.EOF
+	// deadline exceeded
+	case <-pc.rxDeadline.Done():
+		return 0, nil, os.ErrDeadlineExceeded
+	// data available or rxData channel closed
+	case d, ok := <-pc.rxData:
+		if !ok {
+			return 0, nil, io.EOF
+		}
+		n := copy(b, d.payload)
+		return n, d.addr, nil
+	}
+}
+
+// WriteTo writes a packet with payload b to addr.
+// WriteTo can be made to time out and return an error after a fixed
+// time limit; see SetDeadline and SetWriteDeadline.
+func (pc *packetConn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	if pc.writeDelegated {
+		return pc.inner.WriteTo(b, addr)
+	}
+
+	copied := make([]byte, len(b))
+	copy(copied, b)
+
+	result := make(chan txResult, 1)
+
+	select {
+	case <-pc.ctx.Done():
+		return 0, io.EOF
+	case pc.writeReqs <- packetWriteRequest{data: copied, addr: addr, result: result}:
+	}
+
+	select {
+	// connection closed
+	case <-pc.ctx.Done():
+		return 0, io.EOF
+	// deadline exceeded: the write above is still running in the
+	// background, so interrupt it instead of letting its bytes land
+	// on the wire after we have already reported failure.
+	case <-pc.txDeadline.Done():
+		pc.inner.Close()
+		return 0, os.ErrDeadlineExceeded
+	// write completed
+	case result := <-result:
+		return result.n, result.err
+	}
+}
+
+// Close closes the connection.
+func (pc *packetConn) Close() error {
+	defer pc.ctxCancel()
+	return pc.inner.Close()
+}
+
+// LocalAddr returns the local network address, if known.
+func (pc *packetConn) LocalAddr() net.Addr {
+	return pc.inner.LocalAddr()
+}
+
+// SetDeadline sets the read and write deadlines associated with the
+// connection. It is equivalent to calling both SetReadDeadline and
+// SetWriteDeadline.
+//
+// A zero value for t means I/O operations will not time out.
+func (pc *packetConn) SetDeadline(t time.Time) error {
+	if err := pc.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return pc.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future ReadFrom calls
+// and any currently-blocked ReadFrom call.
+// A zero value for t means ReadFrom will not time out.
+func (pc *packetConn) SetReadDeadline(t time.Time) error {
+	if pc.readDelegated {
+		return pc.inner.SetReadDeadline(t)
+	}
+
+	select {
+	case <-pc.ctx.Done():
+		return net.ErrClosed
+	default:
+		pc.rxDeadline.Set(t)
+		return nil
+	}
+}
+
+// SetWriteDeadline sets the deadline for future WriteTo calls
+// and any currently-blocked WriteTo call.
+// A zero value for t means WriteTo will not time out.
+func (pc *packetConn) SetWriteDeadline(t time.Time) error {
+	if pc.writeDelegated {
+		return pc.inner.SetWriteDeadline(t)
+	}
+
+	select {
+	case <-pc.ctx.Done():
+		return net.ErrClosed
+	default:
+		pc.txDeadline.Set(t)
+		return nil
+	}
+}
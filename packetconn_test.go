@@ -0,0 +1,51 @@
+package deaconn
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+var errNoDeadlineSupport = errors.New("fakePacketConn: deadlines not supported")
+
+// fakePacketConn is a net.PacketConn whose SetReadDeadline/SetWriteDeadline
+// always fail, so WithPacketDeadlines falls back to its software-enforced
+// path instead of delegating, and whose ReadFrom blocks until Close.
+type fakePacketConn struct {
+	closed chan struct{}
+}
+
+func newFakePacketConn() *fakePacketConn {
+	return &fakePacketConn{closed: make(chan struct{})}
+}
+
+func (f *fakePacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	<-f.closed
+	return 0, nil, net.ErrClosed
+}
+
+func (f *fakePacketConn) WriteTo(b []byte, addr net.Addr) (int, error) { return len(b), nil }
+
+func (f *fakePacketConn) Close() error {
+	select {
+	case <-f.closed:
+	default:
+		close(f.closed)
+	}
+	return nil
+}
+
+func (f *fakePacketConn) LocalAddr() net.Addr                { return nil }
+func (f *fakePacketConn) SetDeadline(t time.Time) error      { return errNoDeadlineSupport }
+func (f *fakePacketConn) SetReadDeadline(t time.Time) error  { return errNoDeadlineSupport }
+func (f *fakePacketConn) SetWriteDeadline(t time.Time) error { return errNoDeadlineSupport }
+
+func TestPacketConnRxDataIsBounded(t *testing.T) {
+	pc := WithPacketDeadlines(newFakePacketConn()).(*packetConn)
+	defer pc.Close()
+
+	if cap(pc.rxData) != packetRxQueueDepth {
+		t.Fatalf("rxData capacity = %d, want %d", cap(pc.rxData), packetRxQueueDepth)
+	}
+}
@@ -0,0 +1,10 @@
+package deaconn
+
+import "net"
+
+// NewPacketListenerWithDeadlines returns a net.PacketConn implementation
+// which adds deadlines support to a packet-oriented listener, mirroring
+// NewListenerWithDeadlines for stream-oriented listeners.
+func NewPacketListenerWithDeadlines(inner net.PacketConn) net.PacketConn {
+	return WithPacketDeadlines(inner)
+}
@@ -0,0 +1,185 @@
+package deaconn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+const stdioReadBufferSize = 5242880 // 5 MB
+
+var errStdioDeadlineNotSupported = errors.New("deaconn: StdioConn does not support deadlines, wrap it with WithDeadlines")
+
+// stdioAddr is a synthetic net.Addr for StdioConn.
+type stdioAddr struct{}
+
+// Network returns the address's network name.
+func (stdioAddr) Network() string { return "stdio" }
+
+// String returns the address's string representation.
+func (stdioAddr) String() string { return "stdio" }
+
+type stdioOptions struct {
+	closeStdin bool
+}
+
+// StdioOption configures a StdioConn returned by NewStdioConn.
+type StdioOption func(*stdioOptions)
+
+// WithCloseStdin makes Close on the returned StdioConn also close
+// os.Stdin. By default Close leaves os.Stdin open so the process can
+// keep using it after the StdioConn is done with it.
+func WithCloseStdin() StdioOption {
+	return func(o *stdioOptions) { o.closeStdin = true }
+}
+
+// stdioConn is a net.Conn implementation that reads from os.Stdin and
+// writes to os.Stdout.
+type stdioConn struct {
+	closeStdin bool
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	rxData        chan []byte
+	rxDataPending []byte
+}
+
+// NewStdioConn returns a net.Conn implementation that reads from
+// os.Stdin and writes to os.Stdout. It is meant for stdio-based
+// JSON-RPC or IPC transports where the OS provides no native deadline
+// mechanism; compose it with WithDeadlines to add deadline support,
+// e.g. deaconn.WithDeadlines(deaconn.NewStdioConn()).
+func NewStdioConn(opts ...StdioOption) net.Conn {
+	options := stdioOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx, ctxCancel := context.WithCancel(context.Background())
+
+	c := &stdioConn{
+		closeStdin: options.closeStdin,
+
+		ctx:       ctx,
+		ctxCancel: ctxCancel,
+
+		rxData:        make(chan []byte),
+		rxDataPending: []byte{},
+	}
+
+	go c.continouslyReadIntoBuffer()
+
+	return c
+}
+
+// continouslyReadIntoBuffer reads os.Stdin in the background so that
+// Read is selectable against Close. Since os.Stdin.Read is not itself
+// interruptible without closing the file descriptor, a Close that
+// leaves os.Stdin open only stops this goroutine the next time the
+// pending Read call returns.
+func (c *stdioConn) continouslyReadIntoBuffer() {
+	defer close(c.rxData)
+
+	buf := make([]byte, stdioReadBufferSize)
+
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			copied := make([]byte, n)
+			copy(copied, buf[:n])
+
+			select {
+			case c.rxData <- copied:
+			case <-c.ctx.Done():
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read reads data from os.Stdin.
+func (c *stdioConn) Read(b []byte) (int, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	if len(c.rxDataPending) > 0 {
+		n := copy(b, c.rxDataPending)
+		c.rxDataPending = c.rxDataPending[n:]
+		return n, nil
+	}
+
+	select {
+	case <-c.ctx.Done():
+		return 0, io.EOF
+	case data, ok := <-c.rxData:
+		if !ok {
+			return 0, io.EOF
+		}
+
+		n := copy(b, data)
+		if n < len(data) {
+			c.rxDataPending = append(c.rxDataPending, data[n:]...)
+		}
+		return n, nil
+	}
+}
+
+// Write writes data to os.Stdout.
+func (c *stdioConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.ctx.Done():
+		return 0, net.ErrClosed
+	default:
+		return os.Stdout.Write(b)
+	}
+}
+
+// Close makes Read calls that wait for new data return io.EOF (data
+// already buffered from an earlier partial Read is still returned) and
+// signals
+// the internal reader goroutine to stop; that goroutine exits once its
+// pending os.Stdin.Read returns. os.Stdin is left open unless
+// WithCloseStdin was passed to NewStdioConn.
+func (c *stdioConn) Close() error {
+	c.ctxCancel()
+	if c.closeStdin {
+		return os.Stdin.Close()
+	}
+	return nil
+}
+
+// LocalAddr returns the connection's synthetic local address.
+func (c *stdioConn) LocalAddr() net.Addr {
+	return stdioAddr{}
+}
+
+// RemoteAddr returns the connection's synthetic remote address.
+func (c *stdioConn) RemoteAddr() net.Addr {
+	return stdioAddr{}
+}
+
+// SetDeadline is not supported by stdioConn; compose it with
+// WithDeadlines to add deadline support.
+func (c *stdioConn) SetDeadline(t time.Time) error {
+	return errStdioDeadlineNotSupported
+}
+
+// SetReadDeadline is not supported by stdioConn; compose it with
+// WithDeadlines to add deadline support.
+func (c *stdioConn) SetReadDeadline(t time.Time) error {
+	return errStdioDeadlineNotSupported
+}
+
+// SetWriteDeadline is not supported by stdioConn; compose it with
+// WithDeadlines to add deadline support.
+func (c *stdioConn) SetWriteDeadline(t time.Time) error {
+	return errStdioDeadlineNotSupported
+}
@@ -0,0 +1,139 @@
+package deaconn
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// withStdio temporarily swaps os.Stdin and os.Stdout for the in-test
+// pipe ends, restoring the originals on cleanup.
+func withStdio(t *testing.T) (stdinW *os.File, stdoutR *os.File) {
+	t.Helper()
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+
+	origStdin, origStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdinR, stdoutW
+	t.Cleanup(func() {
+		os.Stdin, os.Stdout = origStdin, origStdout
+		stdinR.Close()
+		stdoutW.Close()
+	})
+
+	return stdinW, stdoutR
+}
+
+// newTestStdioConn returns a StdioConn along with a cleanup, registered
+// to run before withStdio's, that closes stdinW (so the background
+// continouslyReadIntoBuffer goroutine observes EOF) and drains rxData
+// until it exits. Without this, that goroutine's next os.Stdin.Read
+// races with withStdio's cleanup reassigning the os.Stdin variable.
+func newTestStdioConn(t *testing.T, stdinW *os.File) net.Conn {
+	t.Helper()
+
+	c := NewStdioConn()
+	sc := c.(*stdioConn)
+	t.Cleanup(func() {
+		stdinW.Close()
+		for range sc.rxData {
+		}
+	})
+	return c
+}
+
+func TestStdioConnReadWrite(t *testing.T) {
+	stdinW, stdoutR := withStdio(t)
+	defer stdoutR.Close()
+
+	c := newTestStdioConn(t, stdinW)
+	defer c.Close()
+
+	go stdinW.Write([]byte("hello"))
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(buf) != "hello" {
+		t.Fatalf("Read = %q, want %q", buf, "hello")
+	}
+
+	if _, err := c.Write([]byte("world")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := io.ReadFull(stdoutR, buf); err != nil {
+		t.Fatalf("reading back stdout: %v", err)
+	}
+	if string(buf) != "world" {
+		t.Fatalf("stdout got %q, want %q", buf, "world")
+	}
+}
+
+func TestStdioConnCloseUnblocksRead(t *testing.T) {
+	stdinW, stdoutR := withStdio(t)
+	defer stdoutR.Close()
+
+	c := newTestStdioConn(t, stdinW)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Fatalf("Read after Close = %v, want io.EOF", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Read did not unblock after Close")
+	}
+}
+
+func TestStdioConnWriteAfterCloseFails(t *testing.T) {
+	stdinW, stdoutR := withStdio(t)
+	defer stdoutR.Close()
+
+	c := newTestStdioConn(t, stdinW)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := c.Write([]byte("x")); err != net.ErrClosed {
+		t.Fatalf("Write after Close = %v, want net.ErrClosed", err)
+	}
+}
+
+func TestStdioConnSetDeadlineUnsupported(t *testing.T) {
+	stdinW, stdoutR := withStdio(t)
+	defer stdoutR.Close()
+
+	c := newTestStdioConn(t, stdinW)
+	defer c.Close()
+
+	if err := c.SetDeadline(time.Now()); err != errStdioDeadlineNotSupported {
+		t.Fatalf("SetDeadline = %v, want errStdioDeadlineNotSupported", err)
+	}
+	if err := c.SetReadDeadline(time.Now()); err != errStdioDeadlineNotSupported {
+		t.Fatalf("SetReadDeadline = %v, want errStdioDeadlineNotSupported", err)
+	}
+	if err := c.SetWriteDeadline(time.Now()); err != errStdioDeadlineNotSupported {
+		t.Fatalf("SetWriteDeadline = %v, want errStdioDeadlineNotSupported", err)
+	}
+}